@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"frodo/store"
+)
+
+// statsPrefix and eventsPrefix namespace the per-shortcode click analytics
+// kept alongside the "code:" and "user:" keyspaces.
+const (
+	statsPrefix  = "stats:"
+	eventsPrefix = "events:"
+)
+
+// clickEvent is one line of the append-only "events:<shortcode>:<unixhour>"
+// log recorded on every successful redirect.
+type clickEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Referer   string    `json:"referer"`
+	UserAgent string    `json:"user_agent_class"`
+}
+
+// classifyUserAgent buckets a User-Agent header into "bot", "mobile" or
+// "desktop" using simple substring matching.
+func classifyUserAgent(ua string) string {
+	lower := strings.ToLower(ua)
+
+	switch {
+	case strings.Contains(lower, "bot") || strings.Contains(lower, "spider") || strings.Contains(lower, "crawl"):
+		return "bot"
+	case strings.Contains(lower, "mobile") || strings.Contains(lower, "android") || strings.Contains(lower, "iphone"):
+		return "mobile"
+	default:
+		return "desktop"
+	}
+}
+
+// recordClick persists a single redirect's analytics: the total click
+// counter and an hour-bucketed event log entry.
+func recordClick(db store.Store, shortCode string, c fiber.Ctx) {
+	if _, err := db.Incr(statsPrefix + shortCode); err != nil {
+		log.Println("error incrementing click count:", err)
+	}
+
+	event := clickEvent{
+		Timestamp: time.Now(),
+		Referer:   c.Get("Referer"),
+		UserAgent: classifyUserAgent(c.Get("User-Agent")),
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Println("error marshaling click event:", err)
+		return
+	}
+
+	hourKey := fmt.Sprintf("%s%s:%d", eventsPrefix, shortCode, event.Timestamp.Truncate(time.Hour).Unix())
+	if err := db.AppendEvent(hourKey, data); err != nil {
+		log.Println("error appending click event:", err)
+	}
+}
+
+// statsHandler returns total clicks, a 24-hour hourly breakdown and the top
+// referers for a short code.
+func statsHandler(c fiber.Ctx, db store.Store) error {
+	shortCode := c.Params("shortcode")
+
+	total, err := db.Get(statsPrefix + shortCode)
+	var totalClicks int64
+	if err == nil {
+		totalClicks, _ = strconv.ParseInt(total, 10, 64)
+	} else if !errors.Is(err, store.ErrNotFound) {
+		log.Println("error getting click count:", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	now := time.Now().Truncate(time.Hour)
+	hourly := make([]fiber.Map, 24)
+	refererCounts := make(map[string]int)
+
+	for i := 0; i < 24; i++ {
+		hour := now.Add(-time.Duration(23-i) * time.Hour)
+		count := 0
+
+		prefix := fmt.Sprintf("%s%s:%d", eventsPrefix, shortCode, hour.Unix())
+		err := db.Iterate(prefix, func(_ string, value string) error {
+			for _, line := range strings.Split(strings.TrimSpace(value), "\n") {
+				if line == "" {
+					continue
+				}
+
+				var ev clickEvent
+				if err := json.Unmarshal([]byte(line), &ev); err != nil {
+					return err
+				}
+
+				count++
+				if ev.Referer != "" {
+					refererCounts[ev.Referer]++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Println("error ranging events:", err)
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}
+
+		hourly[i] = fiber.Map{
+			"hour":   hour.Unix(),
+			"clicks": count,
+		}
+	}
+
+	type referer struct {
+		Referer string `json:"referer"`
+		Clicks  int    `json:"clicks"`
+	}
+
+	topReferers := make([]referer, 0, len(refererCounts))
+	for r, n := range refererCounts {
+		topReferers = append(topReferers, referer{Referer: r, Clicks: n})
+	}
+	sort.Slice(topReferers, func(i, j int) bool {
+		return topReferers[i].Clicks > topReferers[j].Clicks
+	})
+	if len(topReferers) > 5 {
+		topReferers = topReferers[:5]
+	}
+
+	return c.JSON(fiber.Map{
+		"total_clicks": totalClicks,
+		"last_24h":     hourly,
+		"top_referers": topReferers,
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// scopeShortenWrite is the JWT scope required to create or delete short codes.
+const scopeShortenWrite = "shorten:write"
+
+// claims are the custom JWT claims issued on login.
+type claims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// issueToken signs an HS256 JWT for username, valid for ttl.
+func issueToken(secret []byte, username string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Scope: scopeShortenWrite,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+
+	return token.SignedString(secret)
+}
+
+// parseToken validates a bearer token and returns its claims.
+func parseToken(secret []byte, tokenString string) (*claims, error) {
+	parsed, err := jwt.ParseWithClaims(tokenString, &claims{}, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c, ok := parsed.Claims.(*claims)
+	if !ok || !parsed.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return c, nil
+}
+
+// identity is the caller resolved from either a bearer token or the
+// X-API-KEY superuser fallback.
+type identity struct {
+	username  string
+	superuser bool
+}
+
+// identityLocalsKey is the c.Locals key requireIdentity stores the resolved
+// identity under.
+type identityLocalsKey struct{}
+
+// resolveIdentity authenticates the request, checking the X-API-KEY
+// superuser fallback first and then a JWT bearer token.
+func resolveIdentity(c fiber.Ctx, apiKey string, jwtSecret []byte) (identity, error) {
+	if apiKey != "" && c.Get("X-API-KEY") == apiKey {
+		return identity{username: "admin", superuser: true}, nil
+	}
+
+	auth := c.Get("Authorization")
+	tokenString, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok || tokenString == "" {
+		return identity{}, errors.New("missing credentials")
+	}
+
+	tok, err := parseToken(jwtSecret, tokenString)
+	if err != nil {
+		return identity{}, fmt.Errorf("invalid token: %w", err)
+	}
+
+	if tok.Scope != scopeShortenWrite {
+		return identity{}, errors.New("missing required scope")
+	}
+
+	return identity{username: tok.Subject}, nil
+}
+
+// requireIdentity is Fiber middleware that resolves the caller via
+// resolveIdentity and stores it in c.Locals, rejecting the request with 401
+// on failure. Handlers further down the chain retrieve it with
+// identityFromCtx.
+func requireIdentity(apiKey string, jwtSecret []byte) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		id, err := resolveIdentity(c, apiKey, jwtSecret)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		c.Locals(identityLocalsKey{}, id)
+
+		return c.Next()
+	}
+}
+
+// identityFromCtx returns the identity requireIdentity stored in c.Locals.
+func identityFromCtx(c fiber.Ctx) identity {
+	id, _ := c.Locals(identityLocalsKey{}).(identity)
+	return id
+}
+
+// requireSuperuser is Fiber middleware that rejects any request not
+// authenticated as the X-API-KEY superuser, for operational endpoints like
+// /metrics that expose data across all users.
+func requireSuperuser(apiKey string, jwtSecret []byte) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		id, err := resolveIdentity(c, apiKey, jwtSecret)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if !id.superuser {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "superuser access required",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestIssueAndParseToken(t *testing.T) {
+	secret := []byte("test-secret")
+
+	tokenString, err := issueToken(secret, "alice", time.Hour)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+
+	claims, err := parseToken(secret, tokenString)
+	if err != nil {
+		t.Fatalf("parseToken: %v", err)
+	}
+
+	if claims.Subject != "alice" {
+		t.Fatalf("Subject = %q, want %q", claims.Subject, "alice")
+	}
+	if claims.Scope != scopeShortenWrite {
+		t.Fatalf("Scope = %q, want %q", claims.Scope, scopeShortenWrite)
+	}
+}
+
+func TestParseTokenWrongSecret(t *testing.T) {
+	tokenString, err := issueToken([]byte("right-secret"), "alice", time.Hour)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+
+	if _, err := parseToken([]byte("wrong-secret"), tokenString); err == nil {
+		t.Fatal("parseToken with wrong secret: got nil error, want a verification error")
+	}
+}
+
+func TestParseTokenExpired(t *testing.T) {
+	secret := []byte("test-secret")
+
+	tokenString, err := issueToken(secret, "alice", -time.Minute)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+
+	if _, err := parseToken(secret, tokenString); err == nil {
+		t.Fatal("parseToken with expired token: got nil error, want an expiry error")
+	}
+}
+
+// runResolveIdentity sends req through a throwaway Fiber app and returns
+// whatever resolveIdentity resolves to for it.
+func runResolveIdentity(t *testing.T, req *http.Request, apiKey string, jwtSecret []byte) (identity, error) {
+	t.Helper()
+
+	app := fiber.New()
+
+	var id identity
+	var resolveErr error
+
+	app.Get("/", func(c fiber.Ctx) error {
+		id, resolveErr = resolveIdentity(c, apiKey, jwtSecret)
+		return nil
+	})
+
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+
+	return id, resolveErr
+}
+
+func TestResolveIdentityAPIKey(t *testing.T) {
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set("X-API-KEY", "super-secret")
+
+	id, err := runResolveIdentity(t, req, "super-secret", []byte("jwt-secret"))
+	if err != nil {
+		t.Fatalf("resolveIdentity: %v", err)
+	}
+	if !id.superuser {
+		t.Fatal("expected superuser identity for a matching X-API-KEY")
+	}
+}
+
+func TestResolveIdentityBearerToken(t *testing.T) {
+	secret := []byte("jwt-secret")
+	tokenString, err := issueToken(secret, "alice", time.Hour)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+
+	id, err := runResolveIdentity(t, req, "super-secret", secret)
+	if err != nil {
+		t.Fatalf("resolveIdentity: %v", err)
+	}
+	if id.superuser || id.username != "alice" {
+		t.Fatalf("got identity %+v, want {username: alice, superuser: false}", id)
+	}
+}
+
+func TestResolveIdentityMissingCredentials(t *testing.T) {
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+
+	if _, err := runResolveIdentity(t, req, "super-secret", []byte("jwt-secret")); err == nil {
+		t.Fatal("resolveIdentity with no credentials: got nil error, want one")
+	}
+}
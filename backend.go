@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"frodo/store"
+	badgerstore "frodo/store/badger"
+	memorystore "frodo/store/memory"
+	redisstore "frodo/store/redis"
+)
+
+// openStore constructs the storage backend selected by STORE_BACKEND
+// (badger, memory or redis), defaulting to badger to match frodo's
+// historical on-disk behavior. The returned close func releases any
+// resources the backend holds.
+func openStore() (store.Store, func() error, error) {
+	switch backend := os.Getenv("STORE_BACKEND"); backend {
+	case "", "badger":
+		dir := os.Getenv("BADGER_DIR")
+		if dir == "" {
+			dir = "badger"
+		}
+
+		db, err := badgerstore.Open(dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening badger store: %w", err)
+		}
+
+		return db, db.Close, nil
+	case "memory":
+		db := memorystore.New()
+		return db, db.Close, nil
+	case "redis":
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			return nil, nil, fmt.Errorf("REDIS_ADDR is required for STORE_BACKEND=redis")
+		}
+
+		db := redisstore.Open(addr)
+		return db, db.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown STORE_BACKEND %q", backend)
+	}
+}
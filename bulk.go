@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"frodo/store"
+)
+
+// bulkItem is one entry of a bulk shorten request, accepted either as a JSON
+// array element or as a line of an application/x-ndjson stream.
+type bulkItem struct {
+	URL    string `json:"url"`
+	Custom string `json:"custom,omitempty"`
+	Expiry int    `json:"expiry,omitempty"`
+}
+
+// bulkResult is one line of a bulk shorten/delete NDJSON response.
+type bulkResult struct {
+	ShortCode string `json:"short_code,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// decodeBulkItems parses a bulk shorten request body, either a JSON array or
+// an application/x-ndjson stream, without materializing it into an
+// intermediate representation, and enforces maxItems.
+func decodeBulkItems(body []byte, contentType string, maxItems int) ([]bulkItem, error) {
+	var items []bulkItem
+
+	if strings.Contains(contentType, "ndjson") {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			var item bulkItem
+			if err := json.Unmarshal(line, &item); err != nil {
+				return nil, fmt.Errorf("parsing ndjson line %d: %w", len(items)+1, err)
+			}
+
+			items = append(items, item)
+			if len(items) > maxItems {
+				return nil, fmt.Errorf("request exceeds BULK_MAX_ITEMS limit of %d", maxItems)
+			}
+		}
+
+		return items, scanner.Err()
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("parsing json array: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("expected a json array of shorten requests")
+	}
+
+	for dec.More() {
+		var item bulkItem
+		if err := dec.Decode(&item); err != nil {
+			return nil, fmt.Errorf("parsing json array element %d: %w", len(items)+1, err)
+		}
+
+		items = append(items, item)
+		if len(items) > maxItems {
+			return nil, fmt.Errorf("request exceeds BULK_MAX_ITEMS limit of %d", maxItems)
+		}
+	}
+
+	return items, nil
+}
+
+// bulkCreateHandler shortens every item in the request and streams results
+// back in the same order as NDJSON, so large imports don't buffer in memory.
+func bulkCreateHandler(c fiber.Ctx, db store.Store, shortCodeLength, maxItems int, id identity) error {
+	items, err := decodeBulkItems(c.Body(), c.Get(fiber.HeaderContentType), maxItems)
+	if err != nil {
+		shortenTotal.WithLabelValues("error").Inc()
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer w.Flush()
+
+		now := time.Now()
+		entries := make([]store.Entry, 0, len(items))
+		results := make([]bulkResult, len(items))
+
+		for i, item := range items {
+			if item.URL == "" {
+				results[i] = bulkResult{Error: "url is required"}
+				continue
+			}
+
+			code := item.Custom
+			if code == "" {
+				generated, genErr := GenerateRandomString(shortCodeLength)
+				if genErr != nil {
+					results[i] = bulkResult{Error: genErr.Error()}
+					continue
+				}
+				code = generated
+			}
+
+			entry := shortURL{Owner: id.username, URL: item.URL, CreatedAt: now}
+			if item.Expiry > 0 {
+				entry.ExpiresAt = now.Add(time.Duration(item.Expiry) * time.Minute)
+			}
+
+			data, marshalErr := json.Marshal(entry)
+			if marshalErr != nil {
+				results[i] = bulkResult{Error: marshalErr.Error()}
+				continue
+			}
+
+			entries = append(entries, store.Entry{Key: codePrefix + code, Value: string(data), ExpiryMinutes: item.Expiry})
+			results[i] = bulkResult{ShortCode: code}
+		}
+
+		if len(entries) > 0 {
+			if err := db.SetBatch(entries); err != nil {
+				log.Println("error in bulk set:", err)
+				for i := range results {
+					if results[i].ShortCode != "" {
+						results[i] = bulkResult{Error: "failed to store short code"}
+					}
+				}
+			}
+		}
+
+		enc := json.NewEncoder(w)
+		for _, result := range results {
+			if result.Error != "" {
+				shortenTotal.WithLabelValues("error").Inc()
+			} else {
+				shortenTotal.WithLabelValues("success").Inc()
+			}
+
+			if err := enc.Encode(result); err != nil {
+				log.Println("error writing bulk result:", err)
+				return
+			}
+			w.Flush()
+		}
+	})
+
+	return nil
+}
+
+// bulkDeleteHandler deletes every short code in the request, skipping codes
+// the caller doesn't own unless they're a superuser.
+func bulkDeleteHandler(c fiber.Ctx, db store.Store, maxItems int, id identity) error {
+	var codes []string
+	if err := json.Unmarshal(c.Body(), &codes); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "expected a json array of short codes",
+		})
+	}
+
+	if len(codes) > maxItems {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("request exceeds BULK_MAX_ITEMS limit of %d", maxItems),
+		})
+	}
+
+	results := make([]bulkResult, len(codes))
+
+	for i, code := range codes {
+		results[i] = deleteOne(db, code, id)
+	}
+
+	return c.JSON(fiber.Map{
+		"results": results,
+	})
+}
+
+func deleteOne(db store.Store, code string, id identity) bulkResult {
+	raw, err := db.Get(codePrefix + code)
+	if errors.Is(err, store.ErrNotFound) {
+		return bulkResult{ShortCode: code, Error: "not found"}
+	} else if err != nil {
+		log.Println("error getting value:", err)
+		return bulkResult{ShortCode: code, Error: "internal error"}
+	}
+
+	var entry shortURL
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+		log.Println("error unmarshaling short url:", err)
+		return bulkResult{ShortCode: code, Error: "internal error"}
+	}
+
+	if !id.superuser && entry.Owner != id.username {
+		return bulkResult{ShortCode: code, Error: "you do not own this short code"}
+	}
+
+	if err := db.Delete(codePrefix + code); err != nil {
+		log.Println("error deleting key:", err)
+		return bulkResult{ShortCode: code, Error: "internal error"}
+	}
+
+	return bulkResult{ShortCode: code}
+}
+
+// exportHandler streams every short code as NDJSON, restricted to the
+// caller's own codes unless they're a superuser.
+func exportHandler(c fiber.Ctx, db store.Store, id identity) error {
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer w.Flush()
+
+		err := db.Iterate(codePrefix, func(key, value string) error {
+			if !id.superuser {
+				var entry shortURL
+				if err := json.Unmarshal([]byte(value), &entry); err != nil {
+					return err
+				}
+				if entry.Owner != id.username {
+					return nil
+				}
+			}
+
+			line, err := json.Marshal(fiber.Map{
+				"short_code": strings.TrimPrefix(key, codePrefix),
+				"entry":      json.RawMessage(value),
+			})
+			if err != nil {
+				return err
+			}
+
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+			if err := w.WriteByte('\n'); err != nil {
+				return err
+			}
+
+			return w.Flush()
+		})
+		if err != nil {
+			log.Println("error exporting short codes:", err)
+		}
+	})
+
+	return nil
+}
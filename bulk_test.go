@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	memorystore "frodo/store/memory"
+)
+
+// seedShortURL stores a shortURL owned by owner under codePrefix+code.
+func seedShortURL(t *testing.T, db *memorystore.Store, code, owner string) {
+	t.Helper()
+
+	data, err := json.Marshal(shortURL{Owner: owner, URL: "https://example.com", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("marshaling short url: %v", err)
+	}
+
+	if err := db.Set(codePrefix+code, string(data), 0); err != nil {
+		t.Fatalf("seeding short url: %v", err)
+	}
+}
+
+func TestDeleteOneOwner(t *testing.T) {
+	db := memorystore.New()
+	defer db.Close()
+
+	seedShortURL(t, db, "abc123", "alice")
+
+	result := deleteOne(db, "abc123", identity{username: "alice"})
+	if result.Error != "" {
+		t.Fatalf("deleteOne as owner: got error %q, want none", result.Error)
+	}
+
+	if _, err := db.Get(codePrefix + "abc123"); err == nil {
+		t.Fatal("deleteOne as owner: key still exists")
+	}
+}
+
+func TestDeleteOneNonOwner(t *testing.T) {
+	db := memorystore.New()
+	defer db.Close()
+
+	seedShortURL(t, db, "abc123", "alice")
+
+	result := deleteOne(db, "abc123", identity{username: "mallory"})
+	if result.Error != "you do not own this short code" {
+		t.Fatalf("deleteOne as non-owner: got error %q, want ownership error", result.Error)
+	}
+
+	if _, err := db.Get(codePrefix + "abc123"); err != nil {
+		t.Fatal("deleteOne as non-owner: key was deleted")
+	}
+}
+
+func TestDeleteOneSuperuser(t *testing.T) {
+	db := memorystore.New()
+	defer db.Close()
+
+	seedShortURL(t, db, "abc123", "alice")
+
+	result := deleteOne(db, "abc123", identity{username: "admin", superuser: true})
+	if result.Error != "" {
+		t.Fatalf("deleteOne as superuser: got error %q, want none", result.Error)
+	}
+
+	if _, err := db.Get(codePrefix + "abc123"); err == nil {
+		t.Fatal("deleteOne as superuser: key still exists")
+	}
+}
+
+// runExport runs exportHandler as id against db and returns the decoded
+// short codes present in the NDJSON response.
+func runExport(t *testing.T, db *memorystore.Store, id identity) []string {
+	t.Helper()
+
+	app := fiber.New()
+	app.Get("/export", func(c fiber.Ctx) error {
+		return exportHandler(c, db, id)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/export", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var codes []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var decoded struct {
+			ShortCode string `json:"short_code"`
+		}
+		if err := json.Unmarshal(line, &decoded); err != nil {
+			t.Fatalf("decoding export line: %v", err)
+		}
+		codes = append(codes, decoded.ShortCode)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scanning export response: %v", err)
+	}
+
+	return codes
+}
+
+func TestExportHandlerOwnerSeesOnlyOwnCodes(t *testing.T) {
+	db := memorystore.New()
+	defer db.Close()
+
+	seedShortURL(t, db, "mine", "alice")
+	seedShortURL(t, db, "theirs", "bob")
+
+	codes := runExport(t, db, identity{username: "alice"})
+
+	want := []string{"mine"}
+	if !reflect.DeepEqual(codes, want) {
+		t.Fatalf("export as owner: got %v, want %v", codes, want)
+	}
+}
+
+func TestExportHandlerNonOwnerSeesNothing(t *testing.T) {
+	db := memorystore.New()
+	defer db.Close()
+
+	seedShortURL(t, db, "theirs", "bob")
+
+	codes := runExport(t, db, identity{username: "mallory"})
+
+	if len(codes) != 0 {
+		t.Fatalf("export as non-owner: got %v, want no codes", codes)
+	}
+}
+
+func TestExportHandlerSuperuserSeesAllCodes(t *testing.T) {
+	db := memorystore.New()
+	defer db.Close()
+
+	seedShortURL(t, db, "mine", "alice")
+	seedShortURL(t, db, "theirs", "bob")
+
+	codes := runExport(t, db, identity{username: "admin", superuser: true})
+
+	if len(codes) != 2 {
+		t.Fatalf("export as superuser: got %v, want 2 codes", codes)
+	}
+}
+
+func TestDecodeBulkItemsJSONArray(t *testing.T) {
+	body := []byte(`[{"url":"https://a.example"},{"url":"https://b.example","custom":"b","expiry":5}]`)
+
+	items, err := decodeBulkItems(body, "application/json", 10)
+	if err != nil {
+		t.Fatalf("decodeBulkItems: %v", err)
+	}
+
+	want := []bulkItem{
+		{URL: "https://a.example"},
+		{URL: "https://b.example", Custom: "b", Expiry: 5},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("got %+v, want %+v", items, want)
+	}
+}
+
+func TestDecodeBulkItemsNDJSON(t *testing.T) {
+	body := []byte("{\"url\":\"https://a.example\"}\n{\"url\":\"https://b.example\",\"custom\":\"b\"}\n")
+
+	items, err := decodeBulkItems(body, "application/x-ndjson", 10)
+	if err != nil {
+		t.Fatalf("decodeBulkItems: %v", err)
+	}
+
+	want := []bulkItem{
+		{URL: "https://a.example"},
+		{URL: "https://b.example", Custom: "b"},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("got %+v, want %+v", items, want)
+	}
+}
+
+func TestDecodeBulkItemsRejectsNonArray(t *testing.T) {
+	if _, err := decodeBulkItems([]byte(`{"url":"https://a.example"}`), "application/json", 10); err == nil {
+		t.Fatal("decodeBulkItems with a bare object: got nil error, want one")
+	}
+}
+
+func TestDecodeBulkItemsEnforcesMaxItems(t *testing.T) {
+	body := []byte(`[{"url":"https://a.example"},{"url":"https://b.example"},{"url":"https://c.example"}]`)
+
+	if _, err := decodeBulkItems(body, "application/json", 2); err == nil {
+		t.Fatal("decodeBulkItems over the limit: got nil error, want one")
+	}
+}
+
+func TestDecodeBulkItemsEnforcesMaxItemsNDJSON(t *testing.T) {
+	body := []byte("{\"url\":\"https://a.example\"}\n{\"url\":\"https://b.example\"}\n{\"url\":\"https://c.example\"}\n")
+
+	if _, err := decodeBulkItems(body, "application/x-ndjson", 2); err == nil {
+		t.Fatal("decodeBulkItems over the limit: got nil error, want one")
+	}
+}
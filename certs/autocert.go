@@ -0,0 +1,75 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"frodo/store"
+)
+
+// certKeyPrefix namespaces ACME account/certificate data in Store so it can
+// share the keyspace with frodo's other key families.
+const certKeyPrefix = "cert:"
+
+// storeCache adapts Store to autocert.Cache. Store, like store.Store, is
+// string-keyed and string-valued, so binary cert/key material is
+// base64-encoded.
+type storeCache struct {
+	store Store
+}
+
+func (c *storeCache) Get(_ context.Context, name string) ([]byte, error) {
+	encoded, err := c.store.Get(certKeyPrefix + name)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, autocert.ErrCacheMiss
+		}
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+func (c *storeCache) Put(_ context.Context, name string, data []byte) error {
+	return c.store.Set(certKeyPrefix+name, base64.StdEncoding.EncodeToString(data), 0)
+}
+
+func (c *storeCache) Delete(_ context.Context, name string) error {
+	return c.store.Delete(certKeyPrefix + name)
+}
+
+// isNotFound reports whether err indicates a missing key.
+func isNotFound(err error) bool {
+	return errors.Is(err, autocert.ErrCacheMiss) || errors.Is(err, store.ErrNotFound)
+}
+
+// AutocertManager obtains and renews certificates from Let's Encrypt,
+// restricted to the domains in allowedHosts, using store for ACME account
+// and certificate persistence.
+type AutocertManager struct {
+	manager *autocert.Manager
+}
+
+// NewAutocertManager returns a Manager backed by Let's Encrypt.
+func NewAutocertManager(store Store, allowedHosts []string) *AutocertManager {
+	return &AutocertManager{
+		manager: &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      &storeCache{store: store},
+			HostPolicy: autocert.HostWhitelist(allowedHosts...),
+		},
+	}
+}
+
+func (m *AutocertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return m.manager.GetCertificate(hello)
+}
+
+func (m *AutocertManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return m.manager.HTTPHandler(fallback)
+}
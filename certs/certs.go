@@ -0,0 +1,38 @@
+// Package certs manages TLS certificates for frodo's Fiber listener so it
+// can be deployed directly on :443 without a reverse proxy in front of it.
+package certs
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// Mode selects how certificates are obtained, via the TLS_MODE env var.
+type Mode string
+
+const (
+	// ModeAutocert obtains and renews certificates from Let's Encrypt.
+	ModeAutocert Mode = "autocert"
+	// ModeFiles watches a directory of cert/key pairs and hot-reloads them.
+	ModeFiles Mode = "files"
+	// ModeSelfSigned generates an in-memory CA and leaf cert for local dev.
+	ModeSelfSigned Mode = "selfsigned"
+)
+
+// Store is the subset of store.Store used to persist ACME account and
+// certificate data, scoped to avoid a dependency on the main package.
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, value string, expiryMinutes int) error
+	Delete(key string) error
+}
+
+// Manager supplies certificates to a tls.Config via GetCertificate, and
+// optionally serves ACME HTTP-01 challenges on the :80 listener.
+type Manager interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	// HTTPHandler wraps fallback so ACME challenge requests are served
+	// directly and everything else falls through to fallback (normally a
+	// 301 redirect to HTTPS).
+	HTTPHandler(fallback http.Handler) http.Handler
+}
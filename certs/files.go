@@ -0,0 +1,126 @@
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FileManager watches TLSCertDir for *.crt/*.key pairs and hot-reloads them
+// without restarting the process. Pairs are matched by filename stem, e.g.
+// example.com.crt and example.com.key.
+type FileManager struct {
+	dir      string
+	watcher  *fsnotify.Watcher
+	certs    atomic.Pointer[map[string]*tls.Certificate]
+	fallback atomic.Pointer[tls.Certificate]
+}
+
+// NewFileManager loads every certificate pair in dir and starts watching it
+// for changes.
+func NewFileManager(dir string) (*FileManager, error) {
+	m := &FileManager{dir: dir}
+
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("starting cert dir watcher: %w", err)
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		return nil, fmt.Errorf("watching %s: %w", dir, err)
+	}
+
+	m.watcher = watcher
+	go m.watch()
+
+	return m, nil
+}
+
+func (m *FileManager) watch() {
+	for {
+		select {
+		case event, ok := <-m.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := m.reload(); err != nil {
+				log.Println("error reloading certs from", m.dir, ":", err)
+			}
+		case err, ok := <-m.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Println("cert dir watcher error:", err)
+		}
+	}
+}
+
+func (m *FileManager) reload() error {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", m.dir, err)
+	}
+
+	certs := make(map[string]*tls.Certificate)
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".crt") {
+			continue
+		}
+
+		stem := strings.TrimSuffix(entry.Name(), ".crt")
+		certPath := filepath.Join(m.dir, entry.Name())
+		keyPath := filepath.Join(m.dir, stem+".key")
+
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			log.Println("error loading cert pair for", stem, ":", err)
+			continue
+		}
+
+		certs[stem] = &cert
+	}
+
+	m.certs.Store(&certs)
+
+	for _, cert := range certs {
+		m.fallback.Store(cert)
+		break
+	}
+
+	return nil
+}
+
+func (m *FileManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certs := *m.certs.Load()
+
+	if hello.ServerName != "" {
+		if cert, ok := certs[hello.ServerName]; ok {
+			return cert, nil
+		}
+	}
+
+	if fallback := m.fallback.Load(); fallback != nil {
+		return fallback, nil
+	}
+
+	return nil, fmt.Errorf("no certificate available for %q", hello.ServerName)
+}
+
+func (m *FileManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return fallback
+}
@@ -2,95 +2,218 @@ package main
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"errors"
-	"fmt"
 	"log"
 	"math/big"
 	"os"
 	"strconv"
 	"time"
 
-	badger "github.com/dgraph-io/badger/v4"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
 	_ "github.com/joho/godotenv/autoload"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"frodo/store"
+	"frodo/users"
 )
 
-func main() {
-	opts := badger.DefaultOptions("badger")
-	opts.IndexCacheSize = 100 * 1024 * 1024
+// codePrefix namespaces short-code keys so they can share the keyspace with
+// "user:" records without colliding.
+const codePrefix = "code:"
+
+// shortURL is the value stored under "code:<shortcode>".
+type shortURL struct {
+	Owner     string    `json:"owner"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
 
-	db, err := badger.Open(opts)
+func main() {
+	db, closeStore, err := openStore()
 	if err != nil {
 		panic(err)
 	}
+	defer func() {
+		if err := closeStore(); err != nil {
+			log.Println("error closing store:", err)
+		}
+	}()
 
-	defer db.Close()
+	userStore := users.NewStore(db)
+	registerActiveCodesGauge(db)
 
-	kv := &KV{db: db}
 	app := fiber.New()
 	apiKey := os.Getenv("API_KEY")
+	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
+	if len(jwtSecret) == 0 {
+		log.Fatal("JWT_SECRET must be set; an empty HMAC key lets anyone forge a shorten:write token")
+	}
+	auth := requireIdentity(apiKey, jwtSecret)
+
+	jwtExpirySeconds, err := strconv.Atoi(os.Getenv("JWT_EXPIRED_SECOND"))
+	if err != nil {
+		jwtExpirySeconds = 3600
+		log.Println("error parsing JWT_EXPIRED_SECOND, defaulting to 3600")
+	}
+
 	shortCode, err := strconv.Atoi(os.Getenv("SHORT_CODE_LENGTH"))
 	if err != nil {
 		shortCode = 6
 		log.Println("error parsing SHORT_CODE_LENGTH, defaulting to 6")
 	}
 
-	app.Get("/:shortcode", func(c fiber.Ctx) error {
-		return redirectHandler(c, kv)
-	})
+	bulkMaxItems, err := strconv.Atoi(os.Getenv("BULK_MAX_ITEMS"))
+	if err != nil {
+		bulkMaxItems = 1000
+		log.Println("error parsing BULK_MAX_ITEMS, defaulting to 1000")
+	}
 
-	app.Post("/api/shorten", func(c fiber.Ctx) error {
-		if c.Get("X-API-KEY") != apiKey {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "wrong api key",
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()), requireSuperuser(apiKey, jwtSecret))
+
+	app.Get("/healthz", func(c fiber.Ctx) error {
+		if err := db.HealthCheck(c.Context()); err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": err.Error(),
 			})
 		}
 
-		return createHandler(c, kv, shortCode)
+		return c.JSON(fiber.Map{"status": "ok"})
 	})
 
+	app.Get("/api/stats/:shortcode", func(c fiber.Ctx) error {
+		return statsHandler(c, db)
+	})
+
+	app.Get("/:shortcode", func(c fiber.Ctx) error {
+		return redirectHandler(c, db)
+	})
+
+	app.Post("/api/auth/register", func(c fiber.Ctx) error {
+		return registerHandler(c, userStore)
+	})
+
+	app.Post("/api/auth/login", func(c fiber.Ctx) error {
+		return loginHandler(c, userStore, jwtSecret, time.Duration(jwtExpirySeconds)*time.Second)
+	})
+
+	app.Post("/api/shorten", func(c fiber.Ctx) error {
+		return createHandler(c, db, shortCode, identityFromCtx(c))
+	}, auth)
+
 	app.Delete("/api/shorten/:shortcode", func(c fiber.Ctx) error {
-		if c.Get("X-API-KEY") != apiKey {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "wrong api key",
+		return deleteHandler(c, db, identityFromCtx(c))
+	}, auth)
+
+	app.Post("/api/shorten/bulk", func(c fiber.Ctx) error {
+		return bulkCreateHandler(c, db, shortCode, bulkMaxItems, identityFromCtx(c))
+	}, auth)
+
+	app.Delete("/api/shorten/bulk", func(c fiber.Ctx) error {
+		return bulkDeleteHandler(c, db, bulkMaxItems, identityFromCtx(c))
+	}, auth)
+
+	app.Get("/api/export", func(c fiber.Ctx) error {
+		return exportHandler(c, db, identityFromCtx(c))
+	}, auth)
+
+	log.Fatal(serve(app, db))
+}
+
+func registerHandler(c fiber.Ctx, userStore *users.Store) error {
+	name := c.FormValue("username", "")
+	password := c.FormValue("password", "")
+
+	if name == "" || password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "username and password are required",
+		})
+	}
+
+	if err := userStore.Register(name, password); err != nil {
+		if errors.Is(err, users.ErrUserExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "user already exists",
 			})
 		}
 
-		return deleteHandler(c, kv)
-	})
+		log.Println("error registering user:", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
 
-	log.Fatal(app.Listen(":" + os.Getenv("PORT")))
+	return c.SendStatus(fiber.StatusCreated)
 }
 
-func redirectHandler(c fiber.Ctx, db *KV) error {
-	shortCode := c.Params("shortcode")
+func loginHandler(c fiber.Ctx, userStore *users.Store, jwtSecret []byte, ttl time.Duration) error {
+	name := c.FormValue("username", "")
+	password := c.FormValue("password", "")
+
+	if name == "" || password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "username and password are required",
+		})
+	}
+
+	if _, err := userStore.Authenticate(name, password); err != nil {
+		if errors.Is(err, users.ErrUserNotFound) || errors.Is(err, users.ErrInvalidCredentials) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "invalid credentials",
+			})
+		}
 
-	exists, err := db.Exists(shortCode)
+		log.Println("error authenticating user:", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	token, err := issueToken(jwtSecret, name, ttl)
 	if err != nil {
-		log.Println("badger error checking if key exists:", err)
+		log.Println("error issuing token:", err)
 		return c.SendStatus(fiber.StatusInternalServerError)
 	}
 
-	if !exists {
+	return c.JSON(fiber.Map{
+		"token": token,
+	})
+}
+
+func redirectHandler(c fiber.Ctx, db store.Store) error {
+	shortCode := c.Params("shortcode")
+	start := time.Now()
+
+	raw, err := db.Get(codePrefix + shortCode)
+	if errors.Is(err, store.ErrNotFound) {
+		redirectsTotal.WithLabelValues("not_found").Inc()
 		return c.SendStatus(fiber.StatusNotFound)
+	} else if err != nil {
+		log.Println("error getting value:", err)
+		redirectsTotal.WithLabelValues("error").Inc()
+		return c.SendStatus(fiber.StatusInternalServerError)
 	}
 
-	url, err := db.Get(shortCode)
-	if err != nil {
-		log.Println("badger error getting value:", err)
+	var entry shortURL
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+		log.Println("error unmarshaling short url:", err)
+		redirectsTotal.WithLabelValues("error").Inc()
 		return c.SendStatus(fiber.StatusInternalServerError)
 	}
 
-	return c.Redirect().To(url)
+	recordClick(db, shortCode, c)
+	redirectsTotal.WithLabelValues("found").Inc()
+	redirectDuration.Observe(time.Since(start).Seconds())
+
+	return c.Redirect().To(entry.URL)
 }
 
-func createHandler(c fiber.Ctx, db *KV, shortCodeLength int) error {
+func createHandler(c fiber.Ctx, db store.Store, shortCodeLength int, id identity) error {
 	shortCode := c.FormValue("custom", "")
 	url := c.FormValue("url", "")
 	expiry := c.FormValue("expiry", "")
 	expiryMinutes := 0
 
 	if url == "" {
+		shortenTotal.WithLabelValues("error").Inc()
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "url is required",
 		})
@@ -99,12 +222,14 @@ func createHandler(c fiber.Ctx, db *KV, shortCodeLength int) error {
 	if expiry != "" {
 		exp, err := strconv.Atoi(expiry)
 		if err != nil {
+			shortenTotal.WithLabelValues("error").Inc()
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": "expiry must be a number",
 			})
 		}
 
 		if exp < 1 {
+			shortenTotal.WithLabelValues("error").Inc()
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": "expiry must be greater than 0",
 			})
@@ -117,100 +242,84 @@ func createHandler(c fiber.Ctx, db *KV, shortCodeLength int) error {
 		code, err := GenerateRandomString(shortCodeLength)
 		if err != nil {
 			log.Println("error generating random string:", err)
+			shortenTotal.WithLabelValues("error").Inc()
 			return c.SendStatus(fiber.StatusInternalServerError)
 		}
 
 		shortCode = code
 	}
 
-	if err := db.Set(shortCode, url, expiryMinutes); err != nil {
-		log.Println("badger error setting key:", err)
+	now := time.Now()
+	entry := shortURL{
+		Owner:     id.username,
+		URL:       url,
+		CreatedAt: now,
+	}
+	if expiryMinutes > 0 {
+		entry.ExpiresAt = now.Add(time.Duration(expiryMinutes) * time.Minute)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Println("error marshaling short url:", err)
+		shortenTotal.WithLabelValues("error").Inc()
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	if err := db.Set(codePrefix+shortCode, string(data), expiryMinutes); err != nil {
+		log.Println("error setting key:", err)
+		shortenTotal.WithLabelValues("error").Inc()
 		return c.SendStatus(fiber.StatusInternalServerError)
 	}
 
+	shortenTotal.WithLabelValues("success").Inc()
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"short_code": shortCode,
 	})
 }
 
-func deleteHandler(c fiber.Ctx, db *KV) error {
+func deleteHandler(c fiber.Ctx, db store.Store, id identity) error {
 	shortCode := c.Params("shortcode")
 
-	exists, err := db.Exists(shortCode)
-	if err != nil {
-		log.Println("badger error checking if key exists:", err)
-		return c.SendStatus(fiber.StatusInternalServerError)
-	}
-
-	if !exists {
+	raw, err := db.Get(codePrefix + shortCode)
+	if errors.Is(err, store.ErrNotFound) {
 		return c.SendStatus(fiber.StatusNotFound)
+	} else if err != nil {
+		log.Println("error getting value:", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
 	}
 
-	if err := db.Delete(shortCode); err != nil {
-		log.Println("badger error deleting key:", err)
+	var entry shortURL
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+		log.Println("error unmarshaling short url:", err)
 		return c.SendStatus(fiber.StatusInternalServerError)
 	}
 
-	return c.SendStatus(fiber.StatusNoContent)
-}
-
-type KV struct {
-	db *badger.DB
-}
-
-func (k *KV) Exists(key string) (bool, error) {
-	var exists bool
-	err := k.db.View(
-		func(tx *badger.Txn) error {
-			if val, err := tx.Get([]byte(key)); err != nil {
-				return err
-			} else if val != nil {
-				exists = true
-			}
-			return nil
+	if !id.superuser && entry.Owner != id.username {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "you do not own this short code",
 		})
-	if errors.Is(err, badger.ErrKeyNotFound) {
-		err = nil
 	}
-	return exists, err
-}
 
-func (k *KV) Get(key string) (string, error) {
-	var value string
-	return value, k.db.View(
-		func(tx *badger.Txn) error {
-			item, err := tx.Get([]byte(key))
-			if err != nil {
-				return fmt.Errorf("getting value: %w", err)
-			}
-			valCopy, err := item.ValueCopy(nil)
-			if err != nil {
-				return fmt.Errorf("copying value: %w", err)
-			}
-			value = string(valCopy)
-			return nil
-		})
-}
-
-func (k *KV) Set(key, value string, expiryMinutes int) error {
-	return k.db.Update(
-		func(txn *badger.Txn) error {
-			if expiryMinutes == 0 {
-				return txn.Set([]byte(key), []byte(value))
-			}
-
-			entry := badger.NewEntry([]byte(key), []byte(value))
-			entry.WithTTL(time.Duration(expiryMinutes) * time.Minute)
+	if err := db.Delete(codePrefix + shortCode); err != nil {
+		log.Println("error deleting key:", err)
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
 
-			return txn.SetEntry(entry)
-		})
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
-func (k *KV) Delete(key string) error {
-	return k.db.Update(
-		func(txn *badger.Txn) error {
-			return txn.Delete([]byte(key))
-		})
+// countPrefix returns the number of keys stored under prefix, used by
+// frodo_active_codes.
+func countPrefix(db store.Store, prefix string) (int, error) {
+	var count int
+	err := db.Iterate(prefix, func(string, string) error {
+		count++
+		return nil
+	})
+
+	return count, err
 }
 
 func GenerateRandomString(n int) (string, error) {
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"frodo/store"
+)
+
+// Prometheus collectors shared across handlers, scraped via /metrics.
+var (
+	redirectsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "frodo_redirects_total",
+		Help: "Total number of short code redirect attempts, labeled by response status. Per-shortcode counts live in the stats:<shortcode> store, served via GET /api/stats/:shortcode.",
+	}, []string{"status"})
+
+	shortenTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "frodo_shorten_total",
+		Help: "Total number of shorten requests, labeled by result.",
+	}, []string{"result"})
+
+	redirectDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "frodo_redirect_duration_seconds",
+		Help:    "Latency of short code redirect lookups, in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// registerActiveCodesGauge exposes frodo_active_codes as a GaugeFunc that
+// counts db's "code:" entries at scrape time, rather than an in-process
+// counter. A counter would only track this instance's own creates/deletes
+// and drift from the real total once multiple replicas share one backend.
+func registerActiveCodesGauge(db store.Store) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "frodo_active_codes",
+		Help: "Current number of active short codes in the store, counted at scrape time.",
+	}, func() float64 {
+		count, err := countPrefix(db, codePrefix)
+		if err != nil {
+			log.Println("error counting active codes:", err)
+			return 0
+		}
+
+		return float64(count)
+	})
+}
@@ -0,0 +1,251 @@
+// Package badgerstore implements store.Store on top of Badger, frodo's
+// original embedded, on-disk backend.
+package badgerstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+
+	"frodo/store"
+)
+
+const retryAttempts = 10
+
+// Store is a Badger-backed store.Store.
+type Store struct {
+	db *badger.DB
+}
+
+// Open opens (creating if necessary) a Badger database rooted at dir.
+func Open(dir string) (*Store, error) {
+	opts := badger.DefaultOptions(dir)
+	opts.IndexCacheSize = 100 * 1024 * 1024
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close releases the underlying Badger database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) Exists(key string) (bool, error) {
+	var exists bool
+	err := s.db.View(func(tx *badger.Txn) error {
+		if _, err := tx.Get([]byte(key)); err != nil {
+			return err
+		}
+		exists = true
+		return nil
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	return exists, err
+}
+
+func (s *Store) Get(key string) (string, error) {
+	var value string
+	err := s.db.View(func(tx *badger.Txn) error {
+		item, err := tx.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return store.ErrNotFound
+		} else if err != nil {
+			return fmt.Errorf("getting value: %w", err)
+		}
+
+		valCopy, err := item.ValueCopy(nil)
+		if err != nil {
+			return fmt.Errorf("copying value: %w", err)
+		}
+		value = string(valCopy)
+		return nil
+	})
+
+	return value, err
+}
+
+func (s *Store) Set(key, value string, expiryMinutes int) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		if expiryMinutes == 0 {
+			return txn.Set([]byte(key), []byte(value))
+		}
+
+		entry := badger.NewEntry([]byte(key), []byte(value))
+		entry.WithTTL(time.Duration(expiryMinutes) * time.Minute)
+
+		return txn.SetEntry(entry)
+	})
+}
+
+func (s *Store) SetIfNotExists(key, value string, expiryMinutes int) (bool, error) {
+	for attempt := 0; attempt < retryAttempts; attempt++ {
+		var created bool
+
+		err := s.db.Update(func(txn *badger.Txn) error {
+			if _, err := txn.Get([]byte(key)); err == nil {
+				return nil
+			} else if !errors.Is(err, badger.ErrKeyNotFound) {
+				return err
+			}
+
+			if expiryMinutes == 0 {
+				if err := txn.Set([]byte(key), []byte(value)); err != nil {
+					return err
+				}
+			} else {
+				entry := badger.NewEntry([]byte(key), []byte(value)).WithTTL(time.Duration(expiryMinutes) * time.Minute)
+				if err := txn.SetEntry(entry); err != nil {
+					return err
+				}
+			}
+
+			created = true
+			return nil
+		})
+
+		if err == nil {
+			return created, nil
+		}
+		if !errors.Is(err, badger.ErrConflict) {
+			return false, err
+		}
+	}
+
+	return false, fmt.Errorf("setting %s if not exists: too many conflicts", key)
+}
+
+func (s *Store) Delete(key string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	})
+}
+
+func (s *Store) Incr(key string) (int64, error) {
+	var result int64
+
+	for attempt := 0; attempt < retryAttempts; attempt++ {
+		err := s.db.Update(func(txn *badger.Txn) error {
+			var current int64
+
+			item, err := txn.Get([]byte(key))
+			if err == nil {
+				if err := item.Value(func(val []byte) error {
+					current, err = strconv.ParseInt(string(val), 10, 64)
+					return err
+				}); err != nil {
+					return err
+				}
+			} else if !errors.Is(err, badger.ErrKeyNotFound) {
+				return err
+			}
+
+			current++
+			result = current
+
+			return txn.Set([]byte(key), []byte(strconv.FormatInt(current, 10)))
+		})
+
+		if err == nil {
+			return result, nil
+		}
+		if !errors.Is(err, badger.ErrConflict) {
+			return 0, err
+		}
+	}
+
+	return 0, fmt.Errorf("incrementing %s: too many conflicts", key)
+}
+
+func (s *Store) AppendEvent(key string, data []byte) error {
+	for attempt := 0; attempt < retryAttempts; attempt++ {
+		err := s.db.Update(func(txn *badger.Txn) error {
+			var existing []byte
+
+			item, err := txn.Get([]byte(key))
+			if err == nil {
+				if existing, err = item.ValueCopy(nil); err != nil {
+					return err
+				}
+			} else if !errors.Is(err, badger.ErrKeyNotFound) {
+				return err
+			}
+
+			existing = append(existing, data...)
+			existing = append(existing, '\n')
+
+			return txn.Set([]byte(key), existing)
+		})
+
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("appending event to %s: too many conflicts", key)
+}
+
+func (s *Store) SetBatch(entries []store.Entry) error {
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, e := range entries {
+		if e.ExpiryMinutes == 0 {
+			if err := wb.Set([]byte(e.Key), []byte(e.Value)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		entry := badger.NewEntry([]byte(e.Key), []byte(e.Value)).WithTTL(time.Duration(e.ExpiryMinutes) * time.Minute)
+		if err := wb.SetEntry(entry); err != nil {
+			return err
+		}
+	}
+
+	return wb.Flush()
+}
+
+func (s *Store) Iterate(prefix string, fn func(key, value string) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		opts.PrefetchValues = true
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(prefix)); it.ValidForPrefix([]byte(prefix)); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			if err := fn(string(it.Item().Key()), string(val)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// HealthCheck reports whether the database is open and responsive.
+func (s *Store) HealthCheck(_ context.Context) error {
+	return s.db.View(func(*badger.Txn) error {
+		return nil
+	})
+}
@@ -0,0 +1,195 @@
+// Package memorystore implements store.Store in-process with a sync.Map,
+// for tests and low-footprint deployments that don't need persistence.
+package memorystore
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"frodo/store"
+)
+
+const reapInterval = 30 * time.Second
+
+type record struct {
+	value     string
+	expiresAt time.Time // zero means no expiry
+}
+
+// Store is an in-memory store.Store backed by a sync.Map, with a background
+// goroutine reaping expired keys.
+type Store struct {
+	data   sync.Map   // string -> record
+	mu     sync.Mutex // guards read-modify-write ops (Incr, AppendEvent)
+	stopCh chan struct{}
+}
+
+// New returns a Store with its TTL reaper goroutine running. Call Close to
+// stop it.
+func New() *Store {
+	s := &Store{stopCh: make(chan struct{})}
+	go s.reap()
+	return s
+}
+
+// Close stops the TTL reaper goroutine.
+func (s *Store) Close() error {
+	close(s.stopCh)
+	return nil
+}
+
+func (s *Store) reap() {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			s.data.Range(func(key, value any) bool {
+				if rec := value.(record); !rec.expiresAt.IsZero() && now.After(rec.expiresAt) {
+					s.data.Delete(key)
+				}
+				return true
+			})
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *Store) load(key string) (record, bool) {
+	val, ok := s.data.Load(key)
+	if !ok {
+		return record{}, false
+	}
+
+	rec := val.(record)
+	if !rec.expiresAt.IsZero() && time.Now().After(rec.expiresAt) {
+		s.data.Delete(key)
+		return record{}, false
+	}
+
+	return rec, true
+}
+
+func (s *Store) Exists(key string) (bool, error) {
+	_, ok := s.load(key)
+	return ok, nil
+}
+
+func (s *Store) Get(key string) (string, error) {
+	rec, ok := s.load(key)
+	if !ok {
+		return "", store.ErrNotFound
+	}
+	return rec.value, nil
+}
+
+func (s *Store) Set(key, value string, expiryMinutes int) error {
+	rec := record{value: value}
+	if expiryMinutes > 0 {
+		rec.expiresAt = time.Now().Add(time.Duration(expiryMinutes) * time.Minute)
+	}
+
+	s.data.Store(key, rec)
+	return nil
+}
+
+func (s *Store) SetIfNotExists(key, value string, expiryMinutes int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.load(key); ok {
+		return false, nil
+	}
+
+	rec := record{value: value}
+	if expiryMinutes > 0 {
+		rec.expiresAt = time.Now().Add(time.Duration(expiryMinutes) * time.Minute)
+	}
+
+	s.data.Store(key, rec)
+
+	return true, nil
+}
+
+func (s *Store) Delete(key string) error {
+	s.data.Delete(key)
+	return nil
+}
+
+func (s *Store) Incr(key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current int64
+	if rec, ok := s.load(key); ok {
+		parsed, err := strconv.ParseInt(rec.value, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		current = parsed
+	}
+
+	current++
+	s.data.Store(key, record{value: strconv.FormatInt(current, 10)})
+
+	return current, nil
+}
+
+func (s *Store) AppendEvent(key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var existing string
+	if rec, ok := s.load(key); ok {
+		existing = rec.value
+	}
+
+	s.data.Store(key, record{value: existing + string(data) + "\n"})
+	return nil
+}
+
+func (s *Store) SetBatch(entries []store.Entry) error {
+	for _, e := range entries {
+		if err := s.Set(e.Key, e.Value, e.ExpiryMinutes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) Iterate(prefix string, fn func(key, value string) error) error {
+	var rangeErr error
+
+	s.data.Range(func(key, value any) bool {
+		k := key.(string)
+		if !strings.HasPrefix(k, prefix) {
+			return true
+		}
+
+		rec, ok := s.load(k)
+		if !ok {
+			return true
+		}
+
+		if err := fn(k, rec.value); err != nil {
+			rangeErr = err
+			return false
+		}
+
+		return true
+	})
+
+	return rangeErr
+}
+
+// HealthCheck always succeeds; the in-memory store has no external
+// dependency to be unreachable from.
+func (s *Store) HealthCheck(context.Context) error {
+	return nil
+}
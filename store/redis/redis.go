@@ -0,0 +1,120 @@
+// Package redisstore implements store.Store on top of Redis, for horizontal
+// deployments that need to share state behind a load balancer.
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"frodo/store"
+)
+
+// Store is a Redis-backed store.Store.
+type Store struct {
+	client *redis.Client
+}
+
+// Open connects to the Redis instance at addr.
+func Open(addr string) *Store {
+	return &Store{client: redis.NewClient(&redis.Options{Addr: addr})}
+}
+
+// Close closes the underlying Redis client.
+func (s *Store) Close() error {
+	return s.client.Close()
+}
+
+func (s *Store) Exists(key string) (bool, error) {
+	n, err := s.client.Exists(context.Background(), key).Result()
+	return n > 0, err
+}
+
+func (s *Store) Get(key string) (string, error) {
+	value, err := s.client.Get(context.Background(), key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", store.ErrNotFound
+	}
+	return value, err
+}
+
+func (s *Store) Set(key, value string, expiryMinutes int) error {
+	var ttl time.Duration
+	if expiryMinutes > 0 {
+		ttl = time.Duration(expiryMinutes) * time.Minute
+	}
+	return s.client.Set(context.Background(), key, value, ttl).Err()
+}
+
+func (s *Store) SetIfNotExists(key, value string, expiryMinutes int) (bool, error) {
+	var ttl time.Duration
+	if expiryMinutes > 0 {
+		ttl = time.Duration(expiryMinutes) * time.Minute
+	}
+	return s.client.SetNX(context.Background(), key, value, ttl).Result()
+}
+
+func (s *Store) Delete(key string) error {
+	return s.client.Del(context.Background(), key).Err()
+}
+
+func (s *Store) Incr(key string) (int64, error) {
+	return s.client.Incr(context.Background(), key).Result()
+}
+
+func (s *Store) AppendEvent(key string, data []byte) error {
+	return s.client.Append(context.Background(), key, string(data)+"\n").Err()
+}
+
+func (s *Store) SetBatch(entries []store.Entry) error {
+	ctx := context.Background()
+
+	pipe := s.client.Pipeline()
+	for _, e := range entries {
+		var ttl time.Duration
+		if e.ExpiryMinutes > 0 {
+			ttl = time.Duration(e.ExpiryMinutes) * time.Minute
+		}
+		pipe.Set(ctx, e.Key, e.Value, ttl)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *Store) Iterate(prefix string, fn func(key, value string) error) error {
+	ctx := context.Background()
+
+	iter := s.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		value, err := s.client.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+
+	return iter.Err()
+}
+
+// HealthCheck pings the Redis server.
+func (s *Store) HealthCheck(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("pinging redis: %w", err)
+	}
+	return nil
+}
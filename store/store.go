@@ -0,0 +1,49 @@
+// Package store defines the pluggable key/value backend frodo runs on,
+// selected at startup via the STORE_BACKEND env var.
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by Get and Exists-adjacent lookups when a key
+// doesn't exist, independent of the underlying backend.
+var ErrNotFound = errors.New("store: key not found")
+
+// Entry is one key/value pair written by SetBatch.
+type Entry struct {
+	Key           string
+	Value         string
+	ExpiryMinutes int
+}
+
+// Store is implemented by every storage backend frodo can run on.
+type Store interface {
+	// Get returns the value stored at key, or ErrNotFound if it doesn't exist.
+	Get(key string) (string, error)
+	// Set stores value at key, expiring it after expiryMinutes if non-zero.
+	Set(key, value string, expiryMinutes int) error
+	// SetIfNotExists stores value at key and reports true, unless key is
+	// already present, in which case it leaves the store untouched and
+	// reports false. This is a compare-and-swap, atomic even against
+	// concurrent callers racing on the same key.
+	SetIfNotExists(key, value string, expiryMinutes int) (bool, error)
+	// Delete removes key, if present.
+	Delete(key string) error
+	// Exists reports whether key is present, without fetching its value.
+	Exists(key string) (bool, error)
+	// Incr atomically increments the integer stored at key and returns the
+	// new value.
+	Incr(key string) (int64, error)
+	// AppendEvent appends data as a new line to the value stored at key.
+	AppendEvent(key string, data []byte) error
+	// SetBatch writes entries in one batch, for lower write amplification
+	// than one Set per entry.
+	SetBatch(entries []Entry) error
+	// Iterate calls fn with the key and value of every entry stored under
+	// prefix.
+	Iterate(prefix string, fn func(key, value string) error) error
+	// HealthCheck reports whether the backend is reachable and usable.
+	HealthCheck(ctx context.Context) error
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"frodo/certs"
+	"frodo/store"
+)
+
+// serve starts app according to TLS_MODE. With TLS_MODE unset it listens
+// plainly on PORT, matching frodo's historical behavior. Otherwise it
+// terminates TLS on :443 using the selected certs.Manager and serves ACME
+// HTTP-01 challenges (or a 301 redirect to HTTPS) on :80.
+func serve(app *fiber.App, kv store.Store) error {
+	mode := certs.Mode(os.Getenv("TLS_MODE"))
+	if mode == "" {
+		return app.Listen(":" + os.Getenv("PORT"))
+	}
+
+	manager, err := newCertManager(mode, kv)
+	if err != nil {
+		return fmt.Errorf("initializing TLS manager: %w", err)
+	}
+
+	go serveHTTPRedirect(manager)
+
+	ln, err := net.Listen("tcp4", ":443")
+	if err != nil {
+		return fmt.Errorf("listening on :443: %w", err)
+	}
+
+	tlsListener := tls.NewListener(ln, &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: manager.GetCertificate,
+	})
+
+	return app.Listener(tlsListener)
+}
+
+func newCertManager(mode certs.Mode, kv store.Store) (certs.Manager, error) {
+	allowedHosts := splitHosts(os.Getenv("ALLOWED_HOSTS"))
+
+	switch mode {
+	case certs.ModeAutocert:
+		if len(allowedHosts) == 0 {
+			return nil, fmt.Errorf("ALLOWED_HOSTS must list at least one domain for autocert")
+		}
+		return certs.NewAutocertManager(kv, allowedHosts), nil
+	case certs.ModeFiles:
+		dir := os.Getenv("TLS_CERT_DIR")
+		if dir == "" {
+			return nil, fmt.Errorf("TLS_CERT_DIR is required for TLS_MODE=files")
+		}
+		return certs.NewFileManager(dir)
+	case certs.ModeSelfSigned:
+		return certs.NewSelfSignedManager(allowedHosts...)
+	default:
+		return nil, fmt.Errorf("unknown TLS_MODE %q", mode)
+	}
+}
+
+// serveHTTPRedirect runs the :80 listener used for ACME HTTP-01 challenges,
+// 301-redirecting every other request to HTTPS.
+func serveHTTPRedirect(manager certs.Manager) {
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := "https://" + r.Host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+
+	if err := http.ListenAndServe(":80", manager.HTTPHandler(redirect)); err != nil {
+		log.Println(":80 listener error:", err)
+	}
+}
+
+func splitHosts(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+
+	var hosts []string
+	for _, h := range strings.Split(raw, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+
+	return hosts
+}
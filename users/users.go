@@ -0,0 +1,162 @@
+// Package users implements frodo's multi-user subsystem, storing
+// argon2id-hashed passwords so a single deployment can serve several owners
+// whose short codes stay isolated from each other.
+package users
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+
+	"frodo/store"
+)
+
+const keyPrefix = "user:"
+
+const (
+	argonMemory      = 64 * 1024 // 64MiB
+	argonIterations  = 3
+	argonParallelism = 2
+	argonSaltLen     = 16
+	argonKeyLen      = 32
+)
+
+// ErrUserExists is returned by Register when the username is already taken.
+var ErrUserExists = errors.New("users: user already exists")
+
+// ErrUserNotFound is returned when no user matches the given username.
+var ErrUserNotFound = errors.New("users: user not found")
+
+// ErrInvalidCredentials is returned by Authenticate on a bad username/password.
+var ErrInvalidCredentials = errors.New("users: invalid credentials")
+
+// User is the record stored under the "user:<name>" key.
+type User struct {
+	Name         string `json:"name"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// Store manages users on top of frodo's pluggable store.Store.
+type Store struct {
+	db store.Store
+}
+
+// NewStore returns a Store backed by db.
+func NewStore(db store.Store) *Store {
+	return &Store{db: db}
+}
+
+// Register creates a new user with the given password, returning
+// ErrUserExists if the username is already taken. The existence check and
+// write are atomic, so concurrent registrations for the same username can't
+// both succeed.
+func (s *Store) Register(name, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	data, err := json.Marshal(User{Name: name, PasswordHash: hash})
+	if err != nil {
+		return fmt.Errorf("marshaling user: %w", err)
+	}
+
+	created, err := s.db.SetIfNotExists(keyPrefix+name, string(data), 0)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrUserExists
+	}
+
+	return nil
+}
+
+// Authenticate verifies name/password and returns the matching user on
+// success, or ErrInvalidCredentials/ErrUserNotFound on failure.
+func (s *Store) Authenticate(name, password string) (User, error) {
+	user, err := s.Get(name)
+	if err != nil {
+		return User{}, err
+	}
+
+	ok, err := verifyPassword(user.PasswordHash, password)
+	if err != nil {
+		return User{}, fmt.Errorf("verifying password: %w", err)
+	}
+	if !ok {
+		return User{}, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// Get looks up a user by name.
+func (s *Store) Get(name string) (User, error) {
+	var user User
+
+	raw, err := s.db.Get(keyPrefix + name)
+	if errors.Is(err, store.ErrNotFound) {
+		return User{}, ErrUserNotFound
+	} else if err != nil {
+		return User{}, err
+	}
+
+	return user, json.Unmarshal([]byte(raw), &user)
+}
+
+// hashPassword hashes password with argon2id, returning the PHC string form
+// $argon2id$v=19$m=...,t=...,p=...$salt$hash.
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generating salt: %w", err)
+	}
+
+	hash := argon2.IDKey([]byte(password), salt, argonIterations, argonMemory, argonParallelism, argonKeyLen)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonIterations, argonParallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash),
+	), nil
+}
+
+// verifyPassword checks password against a PHC-encoded argon2id hash.
+func verifyPassword(encoded, password string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, fmt.Errorf("malformed hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, fmt.Errorf("parsing version: %w", err)
+	}
+
+	var memory, iterations uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return false, fmt.Errorf("parsing params: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, fmt.Errorf("decoding salt: %w", err)
+	}
+
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, fmt.Errorf("decoding hash: %w", err)
+	}
+
+	got := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(want)))
+
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
@@ -0,0 +1,80 @@
+package users
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	memorystore "frodo/store/memory"
+)
+
+func TestRegisterAndAuthenticate(t *testing.T) {
+	db := memorystore.New()
+	defer db.Close()
+
+	s := NewStore(db)
+
+	if err := s.Register("alice", "hunter2"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if _, err := s.Authenticate("alice", "hunter2"); err != nil {
+		t.Fatalf("Authenticate with correct password: %v", err)
+	}
+
+	if _, err := s.Authenticate("alice", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Authenticate with wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+
+	if _, err := s.Authenticate("bob", "hunter2"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("Authenticate unknown user: got %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	db := memorystore.New()
+	defer db.Close()
+
+	s := NewStore(db)
+
+	if err := s.Register("alice", "hunter2"); err != nil {
+		t.Fatalf("first Register: %v", err)
+	}
+
+	if err := s.Register("alice", "different"); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("second Register: got %v, want ErrUserExists", err)
+	}
+}
+
+func TestRegisterConcurrentDuplicate(t *testing.T) {
+	db := memorystore.New()
+	defer db.Close()
+
+	s := NewStore(db)
+
+	const attempts = 20
+	errs := make([]error, attempts)
+
+	var wg sync.WaitGroup
+	wg.Add(attempts)
+	for i := 0; i < attempts; i++ {
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.Register("racer", "hunter2")
+		}(i)
+	}
+	wg.Wait()
+
+	var successes int
+	for _, err := range errs {
+		if err == nil {
+			successes++
+		} else if !errors.Is(err, ErrUserExists) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if successes != 1 {
+		t.Fatalf("got %d successful registrations, want exactly 1", successes)
+	}
+}